@@ -0,0 +1,195 @@
+// Package auth implements a persistent, multi-tenant token store for the
+// pinning service, replacing a single hardcoded bearer token with scoped,
+// revocable, per-pool-restricted tokens plus optional bearer-JWT
+// verification, so external services can mint their own tokens without
+// sharing a static secret.
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Scope is a permission a token can carry.
+type Scope string
+
+const (
+	ScopePinRead  Scope = "pin:read"
+	ScopePinWrite Scope = "pin:write"
+	ScopeAdmin    Scope = "admin"
+)
+
+// Token is a single issued credential, persisted to disk under
+// Config.StoreDir so it survives a restart.
+type Token struct {
+	Secret  string    `json:"secret"`
+	Scopes  []Scope   `json:"scopes"`
+	Pools   []int     `json:"pools"` // empty means unrestricted, matching Config.AuthorizedPeers semantics
+	Created time.Time `json:"created"`
+	Revoked bool      `json:"revoked"`
+}
+
+// Principal is the authenticated caller a request resolved to, whether
+// from the token Store or a verified bearer JWT. Handlers consult it to
+// decide what the caller may do instead of trusting every caller equally.
+type Principal struct {
+	Subject string
+	Scopes  []Scope
+	Pools   []int // empty means unrestricted
+}
+
+// HasScope reports whether p was granted scope.
+func (p Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPool reports whether p may act on poolID: unrestricted if Pools
+// is empty, otherwise poolID must appear in the list.
+func (p Principal) AllowsPool(poolID int) bool {
+	if len(p.Pools) == 0 {
+		return true
+	}
+	for _, id := range p.Pools {
+		if id == poolID {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is a thread-safe, file-persisted registry of issued tokens, in
+// the same small-JSON-file style as the sync package's height store.
+type Store struct {
+	path string
+
+	mu     sync.Mutex
+	tokens map[string]Token
+}
+
+// NewStore opens (or initializes) the token store at storeDir/tokens.json.
+func NewStore(storeDir string) (*Store, error) {
+	s := &Store{path: filepath.Join(storeDir, "tokens.json"), tokens: map[string]Token{}}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading token store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.tokens); err != nil {
+		return nil, fmt.Errorf("parsing token store: %w", err)
+	}
+	return s, nil
+}
+
+// Create mints a fresh token with the given scopes and pool restrictions
+// and persists it before returning.
+func (s *Store) Create(scopes []Scope, pools []int) (Token, error) {
+	secret, err := randomSecret()
+	if err != nil {
+		return Token{}, fmt.Errorf("generating token secret: %w", err)
+	}
+	tok := Token{Secret: secret, Scopes: scopes, Pools: pools, Created: time.Now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tok.Secret] = tok
+	if err := s.save(); err != nil {
+		delete(s.tokens, tok.Secret)
+		return Token{}, err
+	}
+	return tok, nil
+}
+
+// Revoke marks secret as revoked so future Authenticate calls reject it.
+// It returns false if secret is not a known token.
+func (s *Store) Revoke(secret string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, ok := s.tokens[secret]
+	if !ok {
+		return false, nil
+	}
+	tok.Revoked = true
+	s.tokens[secret] = tok
+	if err := s.save(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Count returns the number of tokens ever issued (including revoked ones).
+func (s *Store) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tokens)
+}
+
+// Authenticate looks up secret and, if it is a known and unrevoked token,
+// returns the Principal it grants.
+func (s *Store) Authenticate(secret string) (Principal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, ok := s.tokens[secret]
+	if !ok || tok.Revoked {
+		return Principal{}, false
+	}
+	return Principal{Subject: secret, Scopes: tok.Scopes, Pools: tok.Pools}, true
+}
+
+// save writes the token store via write-temp-then-rename, so a crash
+// mid-write leaves the previous tokens.json intact instead of a
+// truncated or partially-written file (os.WriteFile alone is not atomic).
+func (s *Store) save() error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s.tokens)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tokens-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temp token store: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("writing temp token store: %w", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("setting temp token store permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("closing temp token store: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("renaming temp token store into place: %w", err)
+	}
+	return nil
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPrincipalHasScope(t *testing.T) {
+	p := Principal{Scopes: []Scope{ScopePinRead, ScopePinWrite}}
+	if !p.HasScope(ScopePinRead) || !p.HasScope(ScopePinWrite) {
+		t.Error("HasScope false for granted scopes")
+	}
+	if p.HasScope(ScopeAdmin) {
+		t.Error("HasScope true for ungranted scope")
+	}
+}
+
+func TestPrincipalAllowsPool(t *testing.T) {
+	unrestricted := Principal{}
+	if !unrestricted.AllowsPool(42) {
+		t.Error("empty Pools should allow any pool")
+	}
+
+	restricted := Principal{Pools: []int{1, 2}}
+	if !restricted.AllowsPool(1) || !restricted.AllowsPool(2) {
+		t.Error("AllowsPool false for an allowed pool")
+	}
+	if restricted.AllowsPool(3) {
+		t.Error("AllowsPool true for a pool not in the list")
+	}
+}
+
+func TestStoreCreateAuthenticateRevoke(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	tok, err := s.Create([]Scope{ScopePinWrite}, []int{1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	p, ok := s.Authenticate(tok.Secret)
+	if !ok {
+		t.Fatal("Authenticate rejected a freshly created token")
+	}
+	if !p.HasScope(ScopePinWrite) || !p.AllowsPool(1) || p.AllowsPool(2) {
+		t.Errorf("unexpected principal from Authenticate: %+v", p)
+	}
+
+	ok, err = s.Revoke(tok.Secret)
+	if err != nil || !ok {
+		t.Fatalf("Revoke: ok=%v err=%v", ok, err)
+	}
+	if _, ok := s.Authenticate(tok.Secret); ok {
+		t.Error("Authenticate accepted a revoked token")
+	}
+
+	if ok, err := s.Revoke("unknown-secret"); ok || err != nil {
+		t.Errorf("Revoke(unknown) = (%v, %v), want (false, nil)", ok, err)
+	}
+}
+
+func TestStorePersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	s1, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	tok, err := s1.Create([]Scope{ScopeAdmin}, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	s2, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("reopening NewStore: %v", err)
+	}
+	if _, ok := s2.Authenticate(tok.Secret); !ok {
+		t.Error("token created by one Store instance was not visible after reopening the store directory")
+	}
+	if s2.Count() != 1 {
+		t.Errorf("Count() = %d, want 1", s2.Count())
+	}
+}
+
+func TestStoreSaveLeavesNoTempFilesBehind(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if _, err := s.Create([]Scope{ScopePinRead}, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, ".tokens-*.json.tmp"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("leftover temp files after save: %v", matches)
+	}
+}
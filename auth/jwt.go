@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTVerifier verifies bearer JWTs against Config.Authorizer's key,
+// letting external services mint their own tokens (scopes and pool
+// restrictions carried as claims) instead of needing a Store-issued
+// shared secret.
+type JWTVerifier struct {
+	// hmacKey and edKey are mutually exclusive: exactly one is set,
+	// depending on whether Config.Authorizer decoded to an HMAC secret
+	// or a 32-byte Ed25519 public key.
+	hmacKey []byte
+	edKey   ed25519.PublicKey
+}
+
+// NewJWTVerifier decodes Config.Authorizer (accepted as hex or URL-safe base64)
+// into a verification key. A 32-byte key is treated as an Ed25519 public
+// key (for EdDSA-signed tokens); any other length is used as an HMAC-SHA256
+// secret (for HS256-signed tokens).
+func NewJWTVerifier(authorizer string) (*JWTVerifier, error) {
+	key, err := decodeKey(authorizer)
+	if err != nil {
+		return nil, fmt.Errorf("decoding authorizer key: %w", err)
+	}
+	if len(key) == ed25519.PublicKeySize {
+		return &JWTVerifier{edKey: ed25519.PublicKey(key)}, nil
+	}
+	return &JWTVerifier{hmacKey: key}, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	if key, err := hex.DecodeString(s); err == nil {
+		return key, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// claims is the subset of the JWT payload the pinning service understands.
+type claims struct {
+	Subject string  `json:"sub"`
+	Scopes  []Scope `json:"scopes"`
+	Pools   []int   `json:"pools"`
+	Expiry  int64   `json:"exp"`
+}
+
+// Verify checks token's signature against v's key and, if valid and
+// unexpired, returns the Principal its claims grant.
+func (v *JWTVerifier) Verify(token string) (Principal, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return Principal{}, false
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &hdr); err != nil {
+		return Principal{}, false
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, false
+	}
+	if !v.verifySignature(hdr.Alg, signingInput, sig) {
+		return Principal{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Principal{}, false
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Principal{}, false
+	}
+	if c.Expiry != 0 && time.Now().Unix() > c.Expiry {
+		return Principal{}, false
+	}
+
+	return Principal{Subject: c.Subject, Scopes: c.Scopes, Pools: c.Pools}, true
+}
+
+func (v *JWTVerifier) verifySignature(alg, signingInput string, sig []byte) bool {
+	switch alg {
+	case "HS256":
+		if v.hmacKey == nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, v.hmacKey)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(mac.Sum(nil), sig)
+	case "EdDSA":
+		if v.edKey == nil {
+			return false
+		}
+		return ed25519.Verify(v.edKey, []byte(signingInput), sig)
+	default:
+		return false
+	}
+}
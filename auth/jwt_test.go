@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshaling segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, key []byte, header, payload any) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	sig := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return signingInput + "." + sig
+}
+
+func signEdDSA(t *testing.T, priv ed25519.PrivateKey, header, payload any) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, payload)
+	sig := ed25519.Sign(priv, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTVerifierHS256RoundTrip(t *testing.T) {
+	key := []byte("super-secret-hmac-key")
+	v, err := NewJWTVerifier(hex.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	token := signHS256(t, key,
+		struct {
+			Alg string `json:"alg"`
+		}{Alg: "HS256"},
+		claims{Subject: "svc-a", Scopes: []Scope{ScopePinWrite}, Pools: []int{1}})
+
+	p, ok := v.Verify(token)
+	if !ok {
+		t.Fatal("Verify rejected a validly signed HS256 token")
+	}
+	if p.Subject != "svc-a" || !p.HasScope(ScopePinWrite) || !p.AllowsPool(1) {
+		t.Errorf("Verify returned unexpected principal: %+v", p)
+	}
+}
+
+func TestJWTVerifierRejectsTamperedSignature(t *testing.T) {
+	key := []byte("super-secret-hmac-key")
+	v, err := NewJWTVerifier(hex.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	token := signHS256(t, key,
+		struct {
+			Alg string `json:"alg"`
+		}{Alg: "HS256"},
+		claims{Subject: "svc-a", Scopes: []Scope{ScopeAdmin}})
+
+	// Corrupt the last byte of the signature segment.
+	parts := []byte(token)
+	parts[len(parts)-1] ^= 0xFF
+	if _, ok := v.Verify(string(parts)); ok {
+		t.Error("Verify accepted a token with a tampered signature")
+	}
+}
+
+func TestJWTVerifierRejectsWrongHMACKey(t *testing.T) {
+	v, err := NewJWTVerifier(hex.EncodeToString([]byte("the-real-key")))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	token := signHS256(t, []byte("an-attackers-guess"),
+		struct {
+			Alg string `json:"alg"`
+		}{Alg: "HS256"},
+		claims{Subject: "attacker", Scopes: []Scope{ScopeAdmin}})
+
+	if _, ok := v.Verify(token); ok {
+		t.Error("Verify accepted a token signed with the wrong HMAC key")
+	}
+}
+
+func TestJWTVerifierEdDSARoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	v, err := NewJWTVerifier(base64.RawURLEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	token := signEdDSA(t, priv,
+		struct {
+			Alg string `json:"alg"`
+		}{Alg: "EdDSA"},
+		claims{Subject: "svc-b", Scopes: []Scope{ScopePinRead}})
+
+	p, ok := v.Verify(token)
+	if !ok {
+		t.Fatal("Verify rejected a validly signed EdDSA token")
+	}
+	if p.Subject != "svc-b" || !p.HasScope(ScopePinRead) {
+		t.Errorf("Verify returned unexpected principal: %+v", p)
+	}
+}
+
+// TestJWTVerifierRejectsAlgConfusion guards against the classic JWT
+// alg-confusion attack: a token whose header claims an algorithm the
+// verifier wasn't configured for (e.g. an Ed25519-configured verifier
+// handed an HS256 token, signed with the public key itself treated as an
+// HMAC secret) must never verify.
+func TestJWTVerifierRejectsAlgConfusion(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	v, err := NewJWTVerifier(base64.RawURLEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	// Attacker signs with HS256 using the known-public Ed25519 key as
+	// the HMAC secret, hoping the verifier will blindly trust hdr.Alg.
+	token := signHS256(t, []byte(pub),
+		struct {
+			Alg string `json:"alg"`
+		}{Alg: "HS256"},
+		claims{Subject: "attacker", Scopes: []Scope{ScopeAdmin}})
+
+	if _, ok := v.Verify(token); ok {
+		t.Error("Verify accepted an alg-confused HS256 token against an Ed25519-only verifier")
+	}
+}
+
+func TestJWTVerifierRejectsUnknownAlg(t *testing.T) {
+	v, err := NewJWTVerifier(hex.EncodeToString([]byte("some-hmac-key")))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	token := encodeSegment(t, struct {
+		Alg string `json:"alg"`
+	}{Alg: "none"}) + "." + encodeSegment(t, claims{Subject: "x"}) + "."
+
+	if _, ok := v.Verify(token); ok {
+		t.Error("Verify accepted a token with alg=none")
+	}
+}
+
+func TestJWTVerifierRejectsExpiredToken(t *testing.T) {
+	key := []byte("super-secret-hmac-key")
+	v, err := NewJWTVerifier(hex.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	token := signHS256(t, key,
+		struct {
+			Alg string `json:"alg"`
+		}{Alg: "HS256"},
+		claims{Subject: "svc-a", Expiry: time.Now().Add(-time.Hour).Unix()})
+
+	if _, ok := v.Verify(token); ok {
+		t.Error("Verify accepted an expired token")
+	}
+}
+
+func TestJWTVerifierRejectsMalformedToken(t *testing.T) {
+	v, err := NewJWTVerifier(hex.EncodeToString([]byte("some-hmac-key")))
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	if _, ok := v.Verify("not-a-jwt"); ok {
+		t.Error("Verify accepted a malformed token")
+	}
+}
@@ -0,0 +1,50 @@
+// Package ipni batches successfully-pinned CIDs into signed IPNI
+// (InterPlanetary Network Indexer) advertisement chains: each
+// advertisement links to the previous one so a pull-based indexer can
+// walk the whole chain, and the publisher can also push Announce
+// messages directly to configured indexer URLs. See
+// https://github.com/ipni/specs for the wire format this is a
+// simplified version of, built without the IPNI client libraries.
+package ipni
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// DefaultChunkSize is how many entries an advertisement holds before a
+// fresh advertisement is started for the remainder.
+const DefaultChunkSize = 1000
+
+// Advertisement is one link in the chain: a signed batch of multihash
+// entries for CIDs this provider now serves.
+type Advertisement struct {
+	ID          string    `json:"id"`
+	PreviousID  string    `json:"previous_id,omitempty"`
+	Provider    string    `json:"provider"`
+	ContextID   string    `json:"context_id"`
+	Entries     []string  `json:"entries"`
+	Signature   []byte    `json:"signature"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// signingBytes returns the deterministic encoding of ad that is signed
+// and that the advertisement's ID is derived from; it excludes the
+// signature and ID fields themselves.
+func signingBytes(ad Advertisement) []byte {
+	ad.Signature = nil
+	ad.ID = ""
+	data, _ := json.Marshal(ad)
+	return data
+}
+
+// adID derives an advertisement's content-addressed ID from its signed
+// bytes, so /ipni/ad/{id} lookups are tamper-evident.
+func adID(signed []byte, sig []byte) string {
+	h := sha256.New()
+	h.Write(signed)
+	h.Write(sig)
+	return hex.EncodeToString(h.Sum(nil))
+}
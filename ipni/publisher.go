@@ -0,0 +1,322 @@
+package ipni
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// StoreDir is where the advertisement chain is persisted, so
+	// restarts continue it rather than forking a new one.
+	StoreDir string
+	// ChunkSize caps how many entries one advertisement carries.
+	ChunkSize int
+	// Interval is how often pending entries are batched into a new
+	// advertisement and (optionally) announced.
+	Interval time.Duration
+	// DirectAnnounce are indexer URLs to POST Announce messages to. If
+	// empty, advertisements are only made available for pull-based
+	// indexers via Publisher.GetAd.
+	DirectAnnounce []string
+	// IdentityKey is the base64-std-encoded, libp2p-marshaled private
+	// key used to sign advertisements.
+	IdentityKey string
+}
+
+// Publisher batches successfully-pinned CIDs and, on Interval, links
+// them into the advertisement chain, signs the result, persists it, and
+// announces it to any configured indexers.
+type Publisher struct {
+	cfg      Config
+	disabled bool
+	identity crypto.PrivKey
+	provider peer.ID
+
+	dir    string
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []string
+	head    string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New constructs a Publisher from cfg. If cfg.IdentityKey is empty, the
+// publisher is a no-op: Enqueue and Start become harmless, since there is
+// nothing to sign ads with.
+func New(cfg Config) (*Publisher, error) {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	p := &Publisher{cfg: cfg, dir: filepath.Join(cfg.StoreDir, "ipni"), client: &http.Client{Timeout: 10 * time.Second}}
+
+	if cfg.IdentityKey == "" {
+		p.disabled = true
+		return p, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(cfg.IdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding ipni publisher identity: %w", err)
+	}
+	priv, err := crypto.UnmarshalPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling ipni publisher identity: %w", err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("deriving provider id: %w", err)
+	}
+	p.identity = priv
+	p.provider = id
+
+	head, err := readHead(p.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading ipni head: %w", err)
+	}
+	p.head = head
+	return p, nil
+}
+
+// Enqueue adds cids to the batch that will be advertised on the next
+// publish tick. It is a no-op if the publisher has no signing identity.
+func (p *Publisher) Enqueue(cids []string) {
+	if p.disabled || len(cids) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, cids...)
+}
+
+// Start launches the publish loop in the background. It is a no-op if
+// the publisher has no signing identity.
+func (p *Publisher) Start(ctx context.Context) error {
+	if p.disabled {
+		return nil
+	}
+	loopCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.loop(loopCtx)
+	return nil
+}
+
+// Stop ends the publish loop and waits for it to exit.
+func (p *Publisher) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+func (p *Publisher) loop(ctx context.Context) {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.publish(); err != nil {
+				log.Printf("ipni: publish failed: %v", err)
+			}
+		}
+	}
+}
+
+// publish drains the pending batch, splits it into Config.ChunkSize
+// advertisements linked to the current chain head, signs and persists
+// each in turn (advancing the head as it goes), and announces the new
+// head to any configured indexers.
+func (p *Publisher) publish() error {
+	p.mu.Lock()
+	batch := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	for start := 0; start < len(batch); start += p.cfg.ChunkSize {
+		end := start + p.cfg.ChunkSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := p.publishChunk(batch[start:end]); err != nil {
+			return err
+		}
+	}
+	return p.announce()
+}
+
+func (p *Publisher) publishChunk(cids []string) error {
+	contextID, err := randomHex(16)
+	if err != nil {
+		return fmt.Errorf("generating context id: %w", err)
+	}
+
+	entries, err := cidsToMultihashes(cids)
+	if err != nil {
+		return err
+	}
+
+	ad := Advertisement{
+		PreviousID:  p.head,
+		Provider:    p.provider.String(),
+		ContextID:   contextID,
+		Entries:     entries,
+		PublishedAt: time.Now(),
+	}
+	sig, err := p.identity.Sign(signingBytes(ad))
+	if err != nil {
+		return fmt.Errorf("signing advertisement: %w", err)
+	}
+	ad.Signature = sig
+	ad.ID = adID(signingBytes(ad), sig)
+
+	if err := p.saveAd(ad); err != nil {
+		return err
+	}
+	if err := writeHead(p.dir, ad.ID); err != nil {
+		return fmt.Errorf("persisting ipni head: %w", err)
+	}
+	p.head = ad.ID
+	log.Printf("ipni: published advertisement %s (%d entries, previous %s)", ad.ID, len(entries), ad.PreviousID)
+	return nil
+}
+
+// announceMessage is the payload POSTed to each DirectAnnounce URL.
+type announceMessage struct {
+	Provider string `json:"provider"`
+	Head     string `json:"head"`
+}
+
+// announce POSTs the current head to every configured direct-announce
+// indexer URL, logging (rather than failing the publish tick) on error
+// since a single unreachable indexer shouldn't block the chain.
+func (p *Publisher) announce() error {
+	if len(p.cfg.DirectAnnounce) == 0 {
+		return nil
+	}
+	msg := announceMessage{Provider: p.provider.String(), Head: p.head}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encoding announce message: %w", err)
+	}
+	for _, url := range p.cfg.DirectAnnounce {
+		resp, err := p.client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("ipni: announce to %s failed: %v", url, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			log.Printf("ipni: announce to %s rejected with status %d", url, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// GetAd returns the advertisement identified by id, for serving over
+// GET /ipni/ad/{id}.
+func (p *Publisher) GetAd(id string) (Advertisement, bool) {
+	data, err := os.ReadFile(p.adPath(id))
+	if err != nil {
+		return Advertisement{}, false
+	}
+	var ad Advertisement
+	if err := json.Unmarshal(data, &ad); err != nil {
+		return Advertisement{}, false
+	}
+	return ad, true
+}
+
+func (p *Publisher) saveAd(ad Advertisement) error {
+	if err := os.MkdirAll(filepath.Join(p.dir, "ads"), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(ad)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.adPath(ad.ID), data, 0o644)
+}
+
+func (p *Publisher) adPath(id string) string {
+	return filepath.Join(p.dir, "ads", id+".json")
+}
+
+// cidsToMultihashes decodes each CID and returns its base58-encoded
+// multihash, which is what an advertisement entry carries per the IPNI
+// wire format (indexers key entries by multihash, not by CID codec).
+func cidsToMultihashes(cids []string) ([]string, error) {
+	entries := make([]string, len(cids))
+	for i, c := range cids {
+		decoded, err := cid.Decode(c)
+		if err != nil {
+			return nil, fmt.Errorf("decoding cid %q: %w", c, err)
+		}
+		entries[i] = decoded.Hash().B58String()
+	}
+	return entries, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func readHead(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "head.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	var payload struct {
+		Head string `json:"head"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", err
+	}
+	return payload.Head, nil
+}
+
+func writeHead(dir, head string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(struct {
+		Head string `json:"head"`
+	}{Head: head})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "head.json"), data, 0o644)
+}
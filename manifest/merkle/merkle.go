@@ -0,0 +1,126 @@
+// Package merkle builds and verifies Merkle proofs over a manifest batch,
+// so a pinning service can reject any CID the blockchain did not actually
+// commit to, instead of trusting a compromised or buggy blockchain
+// endpoint to only ever report authorized CIDs.
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Leaf is the manifest data committed to by one leaf of the tree. Its hash
+// is sha256 of the string "cid|pool_id|replication_factor", with the two
+// integers in decimal and a literal '|' between the fields.
+type Leaf struct {
+	Cid               string
+	PoolID            int
+	ReplicationFactor int
+}
+
+func (l Leaf) hash() [32]byte {
+	data := fmt.Sprintf("%s|%d|%d", l.Cid, l.PoolID, l.ReplicationFactor)
+	return sha256.Sum256([]byte(data))
+}
+
+// Proof lets a verifier walk from a single leaf hash up to a claimed root
+// without needing the rest of the tree.
+type Proof struct {
+	// LeafIndex is this leaf's position among the original leaves,
+	// needed to know whether each sibling combines on the left or right.
+	LeafIndex int `json:"leaf_index"`
+	// Siblings are the hex-encoded sibling hashes, ordered bottom-up.
+	Siblings []string `json:"siblings"`
+}
+
+// Tree is a built Merkle tree plus every layer, kept around so ProofFor
+// can be computed for any leaf without rebuilding.
+type Tree struct {
+	layers [][][32]byte
+}
+
+// Root returns the tree's hex-encoded root hash.
+func (t Tree) Root() string {
+	top := t.layers[len(t.layers)-1]
+	return hex.EncodeToString(top[0][:])
+}
+
+// ProofFor returns the inclusion proof for the leaf at index.
+func (t Tree) ProofFor(index int) (Proof, error) {
+	if index < 0 || index >= len(t.layers[0]) {
+		return Proof{}, fmt.Errorf("leaf index %d out of range", index)
+	}
+	proof := Proof{LeafIndex: index}
+	idx := index
+	for level := 0; level < len(t.layers)-1; level++ {
+		layer := t.layers[level]
+		siblingIdx := idx ^ 1
+		if siblingIdx >= len(layer) {
+			siblingIdx = idx // odd layer: last node was duplicated as its own sibling
+		}
+		proof.Siblings = append(proof.Siblings, hex.EncodeToString(layer[siblingIdx][:]))
+		idx /= 2
+	}
+	return proof, nil
+}
+
+// BuildTree hashes each leaf, then hashes pairs bottom-up, duplicating the
+// last hash of any odd-sized level, until a single root remains.
+func BuildTree(leaves []Leaf) (Tree, error) {
+	if len(leaves) == 0 {
+		return Tree{}, fmt.Errorf("cannot build a merkle tree over zero leaves")
+	}
+
+	bottom := make([][32]byte, len(leaves))
+	for i, l := range leaves {
+		bottom[i] = l.hash()
+	}
+
+	tree := Tree{layers: [][][32]byte{bottom}}
+	current := bottom
+	for len(current) > 1 {
+		next := make([][32]byte, 0, (len(current)+1)/2)
+		for i := 0; i < len(current); i += 2 {
+			left := current[i]
+			right := left
+			if i+1 < len(current) {
+				right = current[i+1]
+			}
+			next = append(next, hashPair(left, right))
+		}
+		tree.layers = append(tree.layers, next)
+		current = next
+	}
+	return tree, nil
+}
+
+// Verify recomputes leaf's hash and walks proof up to root, returning
+// whether the claimed root (hex-encoded) is reproduced.
+func Verify(leaf Leaf, proof Proof, root string) bool {
+	current := leaf.hash()
+	idx := proof.LeafIndex
+	for _, siblingHex := range proof.Siblings {
+		sibling, err := hex.DecodeString(siblingHex)
+		if err != nil || len(sibling) != 32 {
+			return false
+		}
+		var siblingHash [32]byte
+		copy(siblingHash[:], sibling)
+
+		if idx%2 == 0 {
+			current = hashPair(current, siblingHash)
+		} else {
+			current = hashPair(siblingHash, current)
+		}
+		idx /= 2
+	}
+	return hex.EncodeToString(current[:]) == root
+}
+
+func hashPair(left, right [32]byte) [32]byte {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
@@ -0,0 +1,100 @@
+package merkle
+
+import "testing"
+
+func testLeaves() []Leaf {
+	return []Leaf{
+		{Cid: "bafy1", PoolID: 1, ReplicationFactor: 2},
+		{Cid: "bafy2", PoolID: 1, ReplicationFactor: 2},
+		{Cid: "bafy3", PoolID: 2, ReplicationFactor: 3},
+	}
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	leaves := testLeaves()
+	tree, err := BuildTree(leaves)
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	root := tree.Root()
+
+	for i, leaf := range leaves {
+		proof, err := tree.ProofFor(i)
+		if err != nil {
+			t.Fatalf("ProofFor(%d): %v", i, err)
+		}
+		if !Verify(leaf, proof, root) {
+			t.Errorf("Verify(%d) = false, want true", i)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedLeaf(t *testing.T) {
+	leaves := testLeaves()
+	tree, err := BuildTree(leaves)
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	root := tree.Root()
+
+	proof, err := tree.ProofFor(0)
+	if err != nil {
+		t.Fatalf("ProofFor: %v", err)
+	}
+	tampered := leaves[0]
+	tampered.Cid = "bafyEVIL"
+	if Verify(tampered, proof, root) {
+		t.Error("Verify accepted a tampered leaf")
+	}
+}
+
+func TestVerifyRejectsWrongRoot(t *testing.T) {
+	leaves := testLeaves()
+	tree, err := BuildTree(leaves)
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	proof, err := tree.ProofFor(1)
+	if err != nil {
+		t.Fatalf("ProofFor: %v", err)
+	}
+	if Verify(leaves[1], proof, "deadbeef") {
+		t.Error("Verify accepted a bogus root")
+	}
+}
+
+func TestVerifyRejectsMalformedProof(t *testing.T) {
+	leaves := testLeaves()
+	tree, err := BuildTree(leaves)
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	root := tree.Root()
+	proof, err := tree.ProofFor(0)
+	if err != nil {
+		t.Fatalf("ProofFor: %v", err)
+	}
+	proof.Siblings[0] = "not-hex"
+	if Verify(leaves[0], proof, root) {
+		t.Error("Verify accepted a non-hex sibling")
+	}
+}
+
+func TestBuildTreeRejectsEmpty(t *testing.T) {
+	if _, err := BuildTree(nil); err == nil {
+		t.Error("BuildTree(nil) returned no error, want error for zero leaves")
+	}
+}
+
+func TestProofForRejectsOutOfRange(t *testing.T) {
+	tree, err := BuildTree(testLeaves())
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	if _, err := tree.ProofFor(-1); err == nil {
+		t.Error("ProofFor(-1) returned no error")
+	}
+	if _, err := tree.ProofFor(99); err == nil {
+		t.Error("ProofFor(99) returned no error")
+	}
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"go.uber.org/fx"
+
+	"ipfs-pinning-service/auth"
+)
+
+// Authenticator resolves a bearer token to a Principal, consulting the
+// persistent token Store first and falling back to JWT verification
+// against Config.Authorizer's key when no Store-issued token matches, so
+// external services can mint their own tokens without a shared secret.
+type Authenticator struct {
+	Store *auth.Store
+	JWT   *auth.JWTVerifier // nil when Config.Authorizer is unset
+}
+
+// ProvideAuthenticator opens the token store under Config.StoreDir and,
+// if Config.Authorizer is set, constructs the JWT verifier for it.
+func ProvideAuthenticator(cfg *Config) (*Authenticator, error) {
+	store, err := auth.NewStore(cfg.StoreDir)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{Store: store}
+	if cfg.Authorizer != "" {
+		verifier, err := auth.NewJWTVerifier(cfg.Authorizer)
+		if err != nil {
+			return nil, err
+		}
+		a.JWT = verifier
+	}
+
+	// Bootstrap an admin token on first run so the operator has a way to
+	// mint further scoped tokens; once any token exists, rely on those
+	// (or JWTs) instead of minting another one on every restart.
+	if store.Count() == 0 {
+		tok, err := store.Create([]auth.Scope{auth.ScopeAdmin}, nil)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("auth: no tokens found, minted bootstrap admin token: %s", tok.Secret)
+	}
+	return a, nil
+}
+
+// Authenticate resolves token to the Principal it grants, trying the
+// Store before falling back to JWT verification.
+func (a *Authenticator) Authenticate(token string) (auth.Principal, bool) {
+	if principal, ok := a.Store.Authenticate(token); ok {
+		return principal, true
+	}
+	if a.JWT != nil {
+		return a.JWT.Verify(token)
+	}
+	return auth.Principal{}, false
+}
+
+type principalContextKey struct{}
+
+// PrincipalFromContext returns the Principal the request's bearer token
+// resolved to, as attached by Authenticator.Middleware.
+func PrincipalFromContext(ctx context.Context) (auth.Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(auth.Principal)
+	return principal, ok
+}
+
+// Middleware rejects requests that do not carry a bearer token that
+// resolves to a Principal, and attaches that Principal to the request
+// context for handlers to consult.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" || !strings.HasPrefix(header, "Bearer ") {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		token := strings.TrimPrefix(header, "Bearer ")
+		principal, ok := a.Authenticate(token)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalContextKey{}, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireScope rejects the request with 403 unless the authenticated
+// Principal was granted scope.
+func requireScope(w http.ResponseWriter, r *http.Request, scope auth.Scope) (auth.Principal, bool) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok || !principal.HasScope(scope) {
+		writeAPIError(w, http.StatusForbidden, "FORBIDDEN", "missing required scope: "+string(scope))
+		return auth.Principal{}, false
+	}
+	return principal, true
+}
+
+// AuthModule supplies the Authenticator.
+var AuthModule = fx.Module("auth",
+	fx.Provide(ProvideAuthenticator),
+)
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/fx"
+
+	"ipfs-pinning-service/sync"
+)
+
+// DefaultBlockchainEndpoint is the blockchain service's address.
+const DefaultBlockchainEndpoint = "http://127.0.0.1:4000"
+
+// ChainClient posts manifest batches to the blockchain endpoint.
+type ChainClient struct {
+	Endpoint string
+	client   *http.Client
+}
+
+// ProvideChainClient constructs the default ChainClient.
+func ProvideChainClient() *ChainClient {
+	return &ChainClient{
+		Endpoint: DefaultBlockchainEndpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Post sends payload as JSON to endpoint+"/"+action and returns the raw
+// response body, status code, and any transport error.
+func (c *ChainClient) Post(action string, payload interface{}) ([]byte, int, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint+"/"+action, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+// ProvideSyncChain constructs the sync package's own blockchain client,
+// which polls heights/manifests rather than posting batches.
+func ProvideSyncChain() sync.BlockchainClient {
+	return sync.NewHTTPChain(DefaultBlockchainEndpoint)
+}
+
+// ChainModule supplies both blockchain clients the daemon needs.
+var ChainModule = fx.Module("chain",
+	fx.Provide(ProvideChainClient),
+	fx.Provide(ProvideSyncChain),
+)
@@ -0,0 +1,16 @@
+package node
+
+import (
+	ipfsCluster "github.com/ipfs-cluster/ipfs-cluster/api/rest/client"
+	"go.uber.org/fx"
+)
+
+// ProvideClusterClient constructs the default IPFS Cluster REST client.
+func ProvideClusterClient() (ipfsCluster.Client, error) {
+	return ipfsCluster.NewDefaultClient(&ipfsCluster.Config{})
+}
+
+// ClusterModule supplies the IPFS Cluster client.
+var ClusterModule = fx.Module("cluster",
+	fx.Provide(ProvideClusterClient),
+)
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/fx"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultConfigPath is where the daemon reads its configuration from when
+// no override is supplied. Tests and the CLI verifier can bypass the file
+// entirely with fx.Replace(&node.Config{...}).
+const DefaultConfigPath = "/internal/.fula/config.yaml"
+
+// Config mirrors the daemon's on-disk YAML configuration.
+type Config struct {
+	Identity                  string   `yaml:"identity"`
+	StoreDir                  string   `yaml:"storeDir"`
+	PoolName                  string   `yaml:"poolName"`
+	LogLevel                  string   `yaml:"logLevel"`
+	ListenAddrs               []string `yaml:"listenAddrs"`
+	Authorizer                string   `yaml:"authorizer"`
+	AuthorizedPeers           []string `yaml:"authorizedPeers"`
+	IpfsBootstrapNodes        []string `yaml:"ipfsBootstrapNodes"`
+	StaticRelays              []string `yaml:"staticRelays"`
+	ForceReachabilityPrivate  bool     `yaml:"forceReachabilityPrivate"`
+	AllowTransientConnection  bool     `yaml:"allowTransientConnection"`
+	DisableResourceManager    bool     `yaml:"disableResourceManger"`
+	MaxCIDPushRate            int      `yaml:"maxCIDPushRate"`
+	IpniPublishDisabled       bool     `yaml:"ipniPublishDisabled"`
+	IpniPublishInterval       string   `yaml:"ipniPublishInterval"`
+	IpniPublishDirectAnnounce []string `yaml:"IpniPublishDirectAnnounce"`
+	IpniPublisherIdentity     string   `yaml:"ipniPublisherIdentity"`
+	IpniPublishChunkSize      int      `yaml:"ipniPublishChunkSize"`
+}
+
+// ProvideConfig reads Config from DefaultConfigPath. Unlike the old
+// init()/log.Fatalf pattern, a failure here is returned up the Fx
+// lifecycle as an ordinary error instead of killing the process.
+func ProvideConfig() (*Config, error) {
+	data, err := os.ReadFile(DefaultConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+	return &config, nil
+}
+
+// ConfigModule supplies *Config to the rest of the application.
+var ConfigModule = fx.Module("config",
+	fx.Provide(ProvideConfig),
+)
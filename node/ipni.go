@@ -0,0 +1,69 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+
+	"ipfs-pinning-service/ipni"
+)
+
+// DefaultIPNIPublishInterval is used when Config.IpniPublishInterval is
+// unset.
+const DefaultIPNIPublishInterval = time.Hour
+
+// ProvideIPNIPublisher builds the ipni.Publisher from Config's
+// ipniPublish* fields and registers its lifecycle, so the batching loop
+// starts once the daemon is up and stops cleanly on shutdown. A disabled
+// or unconfigured publisher (IpniPublishDisabled, or no
+// IpniPublisherIdentity) is still provided, but Enqueue/Start are no-ops
+// on it.
+func ProvideIPNIPublisher(lc fx.Lifecycle, cfg *Config) (*ipni.Publisher, error) {
+	interval := DefaultIPNIPublishInterval
+	if cfg.IpniPublishInterval != "" {
+		parsed, err := time.ParseDuration(cfg.IpniPublishInterval)
+		if err != nil {
+			return nil, fmt.Errorf("parsing ipniPublishInterval: %w", err)
+		}
+		if parsed <= 0 {
+			return nil, fmt.Errorf("ipniPublishInterval must be positive, got %q", cfg.IpniPublishInterval)
+		}
+		interval = parsed
+	}
+
+	identity := cfg.IpniPublisherIdentity
+	if cfg.IpniPublishDisabled {
+		identity = ""
+	}
+
+	publisher, err := ipni.New(ipni.Config{
+		StoreDir:       cfg.StoreDir,
+		ChunkSize:      cfg.IpniPublishChunkSize,
+		Interval:       interval,
+		DirectAnnounce: cfg.IpniPublishDirectAnnounce,
+		IdentityKey:    identity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// Not ctx: fx cancels it once the start timeout elapses,
+			// which would end the batching loop long before shutdown.
+			return publisher.Start(context.Background())
+		},
+		OnStop: func(ctx context.Context) error {
+			publisher.Stop()
+			return nil
+		},
+	})
+	return publisher, nil
+}
+
+// IPNIModule supplies *ipni.Publisher with its lifecycle already wired up.
+var IPNIModule = fx.Module("ipni",
+	fx.Provide(ProvideIPNIPublisher),
+)
@@ -0,0 +1,35 @@
+package node
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/fx"
+)
+
+// ListenAddr is the daemon's HTTP listen address.
+const ListenAddr = ":8008"
+
+// RunHTTPServer registers the HTTP server's lifecycle: it starts serving
+// in the background on OnStart and shuts down gracefully on OnStop,
+// rather than blocking main() the way http.ListenAndServe used to.
+func RunHTTPServer(lc fx.Lifecycle, router *mux.Router) {
+	srv := &http.Server{Addr: ListenAddr, Handler: router}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				log.Printf("Server is running on %s...", ListenAddr)
+				if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					log.Printf("HTTP server stopped unexpectedly: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	})
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"log"
+
+	"ipfs-pinning-service/manifest/merkle"
+)
+
+// ManifestBatchUploadRequest is posted to the blockchain to register a
+// batch of CIDs against a storage pool.
+type ManifestBatchUploadRequest struct {
+	Cid               []string           `json:"cid"`
+	PoolID            int                `json:"pool_id"`
+	ReplicationFactor []int              `json:"replication_factor"`
+	ManifestMetadata  []ManifestMetadata `json:"manifest_metadata"`
+}
+
+// ManifestBatchUploadResponse is the blockchain's reply. MerkleRoot and
+// Proofs let the caller verify every CID was actually committed to by the
+// batch, rather than trusting the HTTP response blindly.
+type ManifestBatchUploadResponse struct {
+	PoolID     int            `json:"pool_id"`
+	Storer     string         `json:"storer"`
+	Cid        []string       `json:"cid"`
+	MerkleRoot string         `json:"merkle_root"`
+	Proofs     []merkle.Proof `json:"proofs"`
+}
+
+type ManifestMetadata struct {
+	Job ManifestJob `json:"job"`
+}
+
+type ManifestJob struct {
+	Work   string `json:"work"`
+	Engine string `json:"engine"`
+	Uri    string `json:"uri"`
+}
+
+// verifyManifestBatch checks every CID in resp against resp.MerkleRoot
+// using resp.Proofs, using req's per-CID replication factor to reproduce
+// the leaf the blockchain committed to. It returns only the CIDs whose
+// proof verified, so a compromised blockchain endpoint cannot smuggle an
+// unauthorized CID into the cluster by appending it to the response.
+func verifyManifestBatch(req ManifestBatchUploadRequest, resp ManifestBatchUploadResponse) []string {
+	if len(resp.Cid) != len(resp.Proofs) || len(resp.Cid) != len(req.ReplicationFactor) {
+		log.Printf("manifest batch: cid/proof/replication-factor length mismatch, rejecting entire batch")
+		return nil
+	}
+
+	verified := make([]string, 0, len(resp.Cid))
+	for i, cidStr := range resp.Cid {
+		leaf := merkle.Leaf{Cid: cidStr, PoolID: resp.PoolID, ReplicationFactor: req.ReplicationFactor[i]}
+		if !merkle.Verify(leaf, resp.Proofs[i], resp.MerkleRoot) {
+			log.Printf("manifest batch: merkle proof failed for cid %s, refusing to pin", cidStr)
+			continue
+		}
+		verified = append(verified, cidStr)
+	}
+	if len(verified) > 0 {
+		log.Printf("manifest batch: verified merkle root %s for %d cid(s)", resp.MerkleRoot, len(verified))
+	}
+	return verified
+}
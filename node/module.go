@@ -0,0 +1,22 @@
+// Package node wires every subsystem of the pinning-service daemon
+// (config, IPFS Cluster client, blockchain clients, pin store, auth, chain
+// sync, IPNI publisher, and the HTTP router) as Fx providers with OnStart/OnStop lifecycle
+// hooks, so a daemon, a CLI verifier, and integration tests can all
+// compose the same providers instead of depending on init()/log.Fatalf
+// and package-level globals.
+package node
+
+import "go.uber.org/fx"
+
+// Module aggregates every subsystem module. Callers that just want to run
+// the daemon should use fx.New(node.Module, fx.Invoke(node.RunHTTPServer)).
+var Module = fx.Options(
+	ConfigModule,
+	ClusterModule,
+	ChainModule,
+	PinStoreModule,
+	AuthModule,
+	SyncModule,
+	IPNIModule,
+	HTTPModule,
+)
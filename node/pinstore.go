@@ -0,0 +1,17 @@
+package node
+
+import (
+	"go.uber.org/fx"
+
+	"ipfs-pinning-service/pinstore"
+)
+
+// ProvidePinStore constructs the in-memory pin request registry.
+func ProvidePinStore() *pinstore.Store {
+	return pinstore.New()
+}
+
+// PinStoreModule supplies *pinstore.Store.
+var PinStoreModule = fx.Module("pinstore",
+	fx.Provide(ProvidePinStore),
+)
@@ -0,0 +1,466 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	ipfsClusterClientApi "github.com/ipfs-cluster/ipfs-cluster/api"
+	ipfsCluster "github.com/ipfs-cluster/ipfs-cluster/api/rest/client"
+	"github.com/ipfs/go-cid"
+	"go.uber.org/fx"
+
+	"ipfs-pinning-service/auth"
+	"ipfs-pinning-service/ipni"
+	"ipfs-pinning-service/pinstore"
+	"ipfs-pinning-service/sync"
+)
+
+// Server holds every dependency the HTTP handlers need and exposes them
+// as methods, replacing the old package-level globals.
+type Server struct {
+	Config        *Config
+	Cluster       ipfsCluster.Client
+	Chain         *ChainClient
+	PinStore      *pinstore.Store
+	Authenticator *Authenticator
+	SyncManager   *sync.Manager
+	IPNIPublisher *ipni.Publisher
+}
+
+// ProvideServer wires up a Server from its Fx-provided dependencies.
+func ProvideServer(cfg *Config, cluster ipfsCluster.Client, chain *ChainClient, store *pinstore.Store, authenticator *Authenticator, syncMgr *sync.Manager, publisher *ipni.Publisher) *Server {
+	return &Server{
+		Config:        cfg,
+		Cluster:       cluster,
+		Chain:         chain,
+		PinStore:      store,
+		Authenticator: authenticator,
+		SyncManager:   syncMgr,
+		IPNIPublisher: publisher,
+	}
+}
+
+// errEnvelope is the error shape mandated by the pinning-services spec:
+// {"error": {"reason": "...", "details": "..."}}.
+type errEnvelope struct {
+	Error struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	} `json:"error"`
+}
+
+func writeAPIError(w http.ResponseWriter, status int, reason, details string) {
+	var env errEnvelope
+	env.Error.Reason = reason
+	env.Error.Details = details
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(env)
+}
+
+// errForbiddenPool marks an error returned by pinAndRegister as a pool
+// authorization failure rather than an upstream/internal failure, so
+// callers can map it to 403 instead of 500.
+var errForbiddenPool = fmt.Errorf("principal is not authorized for this pool")
+
+// pinAndRegister registers pin on the blockchain manifest ledger, verifies
+// the returned merkle proof, pins the CID in the IPFS Cluster, and
+// returns the PinStatus that should be stored under requestID. It refuses
+// to proceed if principal is not authorized for the configured pool.
+func (s *Server) pinAndRegister(requestID string, pin pinstore.Pin, principal auth.Principal) (pinstore.PinStatus, error) {
+	poolID, err := strconv.Atoi(s.Config.PoolName)
+	if err != nil {
+		return pinstore.PinStatus{}, fmt.Errorf("invalid pool ID configuration: %w", err)
+	}
+	if !principal.AllowsPool(poolID) {
+		return pinstore.PinStatus{}, errForbiddenPool
+	}
+
+	internalRequest := ManifestBatchUploadRequest{
+		Cid:               []string{pin.CID},
+		PoolID:            poolID,
+		ReplicationFactor: []int{1},
+		ManifestMetadata: []ManifestMetadata{
+			{
+				Job: ManifestJob{
+					Work:   "storage",
+					Engine: "IPFS",
+					Uri:    pin.CID,
+				},
+			},
+		},
+	}
+
+	resp, statusCode, err := s.Chain.Post("fula-manifest-batch_upload", internalRequest)
+	if err != nil {
+		return pinstore.PinStatus{}, fmt.Errorf("blockchain interaction failed: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return pinstore.PinStatus{}, fmt.Errorf("blockchain returned status %d", statusCode)
+	}
+
+	var blockchainResp ManifestBatchUploadResponse
+	if err := json.Unmarshal(resp, &blockchainResp); err != nil {
+		return pinstore.PinStatus{}, fmt.Errorf("decoding blockchain response: %w", err)
+	}
+
+	verified := verifyManifestBatch(internalRequest, blockchainResp)
+	if len(verified) != 1 || verified[0] != pin.CID {
+		return pinstore.PinStatus{}, fmt.Errorf("merkle proof verification failed for cid %s", pin.CID)
+	}
+
+	c, err := cid.Decode(pin.CID)
+	if err != nil {
+		return pinstore.PinStatus{}, fmt.Errorf("invalid cid: %w", err)
+	}
+	pinOptions := ipfsClusterClientApi.PinOptions{Mode: ipfsClusterClientApi.PinModeRecursive}
+	if _, err := s.Cluster.Pin(context.Background(), ipfsClusterClientApi.NewCid(c), pinOptions); err != nil {
+		return pinstore.PinStatus{}, fmt.Errorf("pinning cid in cluster: %w", err)
+	}
+	s.IPNIPublisher.Enqueue([]string{pin.CID})
+
+	return pinstore.PinStatus{
+		RequestID:  requestID,
+		Status:     pinstore.StatusPinning,
+		Created:    time.Now(),
+		Pin:        pin,
+		Delegates:  []string{fmt.Sprintf("/dns4/pools%d.functionyard.fula.network/tcp/4001/p2p/QmServicePeerId", blockchainResp.PoolID)},
+		Info:       map[string]string{"storer": blockchainResp.Storer},
+		PoolID:     blockchainResp.PoolID,
+		ClusterCid: pin.CID,
+	}, nil
+}
+
+// unpinFromCluster best-effort unpins cidStr, logging (rather than
+// failing the request) on error, since the pin may already have been
+// evicted by cluster-side garbage collection.
+func (s *Server) unpinFromCluster(cidStr string) {
+	if cidStr == "" {
+		return
+	}
+	c, err := cid.Decode(cidStr)
+	if err != nil {
+		log.Printf("Failed to decode cid %s for unpin: %v", cidStr, err)
+		return
+	}
+	if _, err := s.Cluster.Unpin(context.Background(), ipfsClusterClientApi.NewCid(c)); err != nil {
+		log.Printf("Failed to unpin cid %s: %v", cidStr, err)
+	}
+}
+
+// HandleAddPin implements POST /pins.
+func (s *Server) HandleAddPin(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireScope(w, r, auth.ScopePinWrite)
+	if !ok {
+		return
+	}
+
+	var pin pinstore.Pin
+	if err := json.NewDecoder(r.Body).Decode(&pin); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	if pin.CID == "" {
+		writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "cid is required")
+		return
+	}
+
+	requestID, err := pinstore.NewRequestID()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", err.Error())
+		return
+	}
+
+	status, err := s.pinAndRegister(requestID, pin, principal)
+	if err != nil {
+		if err == errForbiddenPool {
+			writeAPIError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+			return
+		}
+		log.Println("Failed to add pin:", err)
+		writeAPIError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", err.Error())
+		return
+	}
+	s.PinStore.Put(status)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleListPins implements GET /pins.
+func (s *Server) HandleListPins(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, auth.ScopePinRead); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := pinstore.ListFilter{
+		Match: q.Get("match"),
+		Name:  q.Get("name"),
+	}
+	if v := q.Get("cid"); v != "" {
+		filter.CIDs = strings.Split(v, ",")
+	}
+	if v := q.Get("status"); v != "" {
+		for _, st := range strings.Split(v, ",") {
+			filter.Statuses = append(filter.Statuses, pinstore.Status(st))
+		}
+	}
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid before timestamp")
+			return
+		}
+		filter.Before = &t
+	}
+	if v := q.Get("after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid after timestamp")
+			return
+		}
+		filter.After = &t
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+	if v := q.Get("meta"); v != "" {
+		meta := map[string]string{}
+		if err := json.Unmarshal([]byte(v), &meta); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid meta")
+			return
+		}
+		filter.Meta = meta
+	}
+
+	results, count := s.PinStore.List(filter)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Count   int                  `json:"count"`
+		Results []pinstore.PinStatus `json:"results"`
+	}{Count: count, Results: results})
+}
+
+// HandleGetPin implements GET /pins/{requestid}.
+func (s *Server) HandleGetPin(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, auth.ScopePinRead); !ok {
+		return
+	}
+
+	requestID := mux.Vars(r)["requestid"]
+	status, ok := s.PinStore.Get(requestID)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "NOT_FOUND", "requestid not found")
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleReplacePin implements POST /pins/{requestid}.
+func (s *Server) HandleReplacePin(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requireScope(w, r, auth.ScopePinWrite)
+	if !ok {
+		return
+	}
+
+	requestID := mux.Vars(r)["requestid"]
+	existing, ok := s.PinStore.Get(requestID)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "NOT_FOUND", "requestid not found")
+		return
+	}
+
+	var pin pinstore.Pin
+	if err := json.NewDecoder(r.Body).Decode(&pin); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	if pin.CID == "" {
+		writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "cid is required")
+		return
+	}
+
+	status, err := s.pinAndRegister(requestID, pin, principal)
+	if err != nil {
+		if err == errForbiddenPool {
+			writeAPIError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+			return
+		}
+		log.Println("Failed to replace pin:", err)
+		writeAPIError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", err.Error())
+		return
+	}
+	if existing.ClusterCid != status.ClusterCid {
+		s.unpinFromCluster(existing.ClusterCid)
+	}
+	s.PinStore.Put(status)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleDeletePin implements DELETE /pins/{requestid}.
+func (s *Server) HandleDeletePin(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, auth.ScopePinWrite); !ok {
+		return
+	}
+
+	requestID := mux.Vars(r)["requestid"]
+	status, ok := s.PinStore.Delete(requestID)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "NOT_FOUND", "requestid not found")
+		return
+	}
+	s.unpinFromCluster(status.ClusterCid)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleManifestBatchUpload registers a batch of CIDs on the blockchain
+// and pins every CID whose merkle proof verifies. ProvideRouter does not
+// register it on any route.
+func (s *Server) HandleManifestBatchUpload(w http.ResponseWriter, r *http.Request) {
+	var req ManifestBatchUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, statusCode, err := s.Chain.Post("fula-manifest-batch_upload", req)
+	if err != nil {
+		log.Println("Failed to register CIDs on blockchain:", err)
+		http.Error(w, "Blockchain interaction failed", statusCode)
+		return
+	}
+
+	var blockchainResp ManifestBatchUploadResponse
+	if err := json.Unmarshal(resp, &blockchainResp); err != nil {
+		log.Println("Failed to decode blockchain response:", err)
+		http.Error(w, "Failed to decode blockchain response", http.StatusInternalServerError)
+		return
+	}
+
+	pinOptions := ipfsClusterClientApi.PinOptions{Mode: ipfsClusterClientApi.PinModeRecursive}
+	var pinned []string
+	for _, cidStr := range verifyManifestBatch(req, blockchainResp) {
+		c, _ := cid.Decode(cidStr)
+		if _, err := s.Cluster.Pin(context.Background(), ipfsClusterClientApi.NewCid(c), pinOptions); err != nil {
+			log.Printf("Failed to pin CID %s: %v", cidStr, err)
+			continue
+		}
+		pinned = append(pinned, cidStr)
+	}
+	s.IPNIPublisher.Enqueue(pinned)
+
+	fmt.Fprintf(w, "CIDs pinned successfully: %v", pinned)
+}
+
+// HandleSyncStatus implements GET /sync/status.
+func (s *Server) HandleSyncStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.SyncManager.Status())
+}
+
+// createTokenRequest is the body accepted by POST /admin/tokens.
+type createTokenRequest struct {
+	Scopes []auth.Scope `json:"scopes"`
+	Pools  []int        `json:"pools"`
+}
+
+// HandleCreateToken implements the admin-only POST /admin/tokens: it
+// mints a new persistent token with the requested scopes and pool
+// restrictions.
+func (s *Server) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, auth.ScopeAdmin); !ok {
+		return
+	}
+
+	var req createTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	if len(req.Scopes) == 0 {
+		writeAPIError(w, http.StatusBadRequest, "BAD_REQUEST", "scopes is required")
+		return
+	}
+
+	tok, err := s.Authenticator.Store.Create(req.Scopes, req.Pools)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tok)
+}
+
+// HandleRevokeToken implements the admin-only DELETE /admin/tokens/{secret}.
+func (s *Server) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireScope(w, r, auth.ScopeAdmin); !ok {
+		return
+	}
+
+	secret := mux.Vars(r)["secret"]
+	revoked, err := s.Authenticator.Store.Revoke(secret)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", err.Error())
+		return
+	}
+	if !revoked {
+		writeAPIError(w, http.StatusNotFound, "NOT_FOUND", "token not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleIPNIAd implements GET /ipni/ad/{id}: it serves one advertisement
+// from the publisher's chain so pull-based indexers can walk it. Unlike
+// the rest of the API, it is not behind Authenticator.Middleware, since
+// indexers fetching ads have no pinning-service token.
+func (s *Server) HandleIPNIAd(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	ad, ok := s.IPNIPublisher.GetAd(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ad)
+}
+
+// ProvideRouter assembles the mux.Router from a Server's handlers.
+func ProvideRouter(s *Server) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/ipni/ad/{id}", s.HandleIPNIAd).Methods("GET")
+
+	apiRouter := r.PathPrefix("").Subrouter()
+	apiRouter.Use(s.Authenticator.Middleware)
+	apiRouter.HandleFunc("/pins", s.HandleListPins).Methods("GET")
+	apiRouter.HandleFunc("/pins", s.HandleAddPin).Methods("POST")
+	apiRouter.HandleFunc("/pins/{requestid}", s.HandleGetPin).Methods("GET")
+	apiRouter.HandleFunc("/pins/{requestid}", s.HandleReplacePin).Methods("POST")
+	apiRouter.HandleFunc("/pins/{requestid}", s.HandleDeletePin).Methods("DELETE")
+	apiRouter.HandleFunc("/sync/status", s.HandleSyncStatus).Methods("GET")
+	apiRouter.HandleFunc("/admin/tokens", s.HandleCreateToken).Methods("POST")
+	apiRouter.HandleFunc("/admin/tokens/{secret}", s.HandleRevokeToken).Methods("DELETE")
+	return r
+}
+
+// HTTPModule supplies *Server and the *mux.Router built from it.
+var HTTPModule = fx.Module("http",
+	fx.Provide(ProvideServer),
+	fx.Provide(ProvideRouter),
+)
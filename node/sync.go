@@ -0,0 +1,43 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	ipfsCluster "github.com/ipfs-cluster/ipfs-cluster/api/rest/client"
+	"go.uber.org/fx"
+
+	"ipfs-pinning-service/sync"
+)
+
+// syncPollInterval is how often the steady-state sync loop polls the
+// blockchain manifest ledger for new heights.
+const syncPollInterval = 30 * time.Second
+
+// ProvideSyncManager constructs the sync.Manager and registers its
+// Start/Stop with the Fx lifecycle, so the initial catch-up runs (and can
+// fail the app startup) before the HTTP server begins serving, and the
+// steady-state loop is stopped cleanly on shutdown.
+func ProvideSyncManager(lc fx.Lifecycle, cfg *Config, cluster ipfsCluster.Client, chain sync.BlockchainClient) (*sync.Manager, error) {
+	manager, err := sync.NewManager(chain, cluster, cfg.StoreDir, syncPollInterval)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// Not ctx: fx cancels it once the start timeout elapses,
+			// which would end the steady-state loop long before shutdown.
+			return manager.Start(context.Background())
+		},
+		OnStop: func(ctx context.Context) error {
+			manager.Stop()
+			return nil
+		},
+	})
+	return manager, nil
+}
+
+// SyncModule supplies *sync.Manager with its lifecycle already wired up.
+var SyncModule = fx.Module("sync",
+	fx.Provide(ProvideSyncManager),
+)
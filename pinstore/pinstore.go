@@ -0,0 +1,215 @@
+// Package pinstore implements the request/response model and lookup
+// semantics of the IPFS Pinning Services API (https://ipfs.github.io/pinning-services-api-spec/),
+// mapping a pinning-service requestid to the underlying IPFS Cluster pin
+// operation and blockchain manifest state that produced it.
+package pinstore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is a pin's lifecycle state, as defined by the pinning-services spec.
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusPinning Status = "pinning"
+	StatusPinned  Status = "pinned"
+	StatusFailed  Status = "failed"
+)
+
+// Pin is the user-supplied object describing what to pin.
+type Pin struct {
+	CID     string            `json:"cid"`
+	Name    string            `json:"name,omitempty"`
+	Origins []string          `json:"origins,omitempty"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// PinStatus is the full object returned to callers for a pin request.
+type PinStatus struct {
+	RequestID string            `json:"requestid"`
+	Status    Status            `json:"status"`
+	Created   time.Time         `json:"created"`
+	Pin       Pin               `json:"pin"`
+	Delegates []string          `json:"delegates,omitempty"`
+	Info      map[string]string `json:"info,omitempty"`
+
+	// PoolID and ClusterCid record the blockchain pool and the CID string
+	// that was decoded and handed to the cluster's Pin call, so Replace
+	// and Delete know what to unpin.
+	PoolID     int    `json:"-"`
+	ClusterCid string `json:"-"`
+}
+
+// CreatedRFC3339 renders p's timestamp in the RFC3339 form the spec
+// requires; Go's default time.Time marshaling uses RFC3339Nano instead.
+func (p PinStatus) CreatedRFC3339() string {
+	return p.Created.UTC().Format(time.RFC3339)
+}
+
+// MarshalJSON serializes p with Created in the spec-required RFC3339
+// form rather than the RFC3339Nano the default time.Time encoding uses.
+func (p PinStatus) MarshalJSON() ([]byte, error) {
+	type alias PinStatus
+	return json.Marshal(struct {
+		alias
+		Created string `json:"created"`
+	}{
+		alias:   alias(p),
+		Created: p.CreatedRFC3339(),
+	})
+}
+
+// ListFilter holds the query parameters accepted by GET /pins.
+type ListFilter struct {
+	CIDs     []string
+	Name     string
+	Match    string // "exact", "iexact", "partial", "ipartial"
+	Statuses []Status
+	Before   *time.Time
+	After    *time.Time
+	Limit    int
+	Meta     map[string]string
+}
+
+// Store is a thread-safe, in-memory registry of pin requests keyed by
+// requestid. It is the single source of truth the HTTP handlers consult
+// when translating pinning-service calls into cluster/blockchain calls.
+type Store struct {
+	mu   sync.RWMutex
+	pins map[string]PinStatus
+}
+
+// New returns an empty Store.
+func New() *Store {
+	return &Store{pins: make(map[string]PinStatus)}
+}
+
+// NewRequestID returns a fresh, unused requestid.
+func NewRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating requestid: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Put inserts or overwrites the pin status for requestid.
+func (s *Store) Put(status PinStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[status.RequestID] = status
+}
+
+// Get returns the pin status for requestid, if any.
+func (s *Store) Get(requestID string) (PinStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status, ok := s.pins[requestID]
+	return status, ok
+}
+
+// Delete removes requestid from the store, returning the removed status.
+func (s *Store) Delete(requestID string) (PinStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.pins[requestID]
+	if ok {
+		delete(s.pins, requestID)
+	}
+	return status, ok
+}
+
+// List returns the pin statuses matching filter, newest-first, along with
+// the total number of matches before limit was applied (used for the
+// spec's "count" response field).
+func (s *Store) List(filter ListFilter) ([]PinStatus, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]PinStatus, 0, len(s.pins))
+	for _, p := range s.pins {
+		if matchesFilter(p, filter) {
+			matches = append(matches, p)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Created.After(matches[j].Created)
+	})
+
+	total := len(matches)
+	limit := filter.Limit
+	switch {
+	case limit <= 0:
+		limit = 10 // spec default
+	case limit > 1000:
+		limit = 1000 // spec max
+	}
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, total
+}
+
+func matchesFilter(p PinStatus, f ListFilter) bool {
+	if len(f.CIDs) > 0 && !contains(f.CIDs, p.Pin.CID) {
+		return false
+	}
+	if f.Name != "" && !matchName(p.Pin.Name, f.Name, f.Match) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !containsStatus(f.Statuses, p.Status) {
+		return false
+	}
+	if f.Before != nil && !p.Created.Before(*f.Before) {
+		return false
+	}
+	if f.After != nil && !p.Created.After(*f.After) {
+		return false
+	}
+	for k, v := range f.Meta {
+		if p.Pin.Meta[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func matchName(name, want, mode string) bool {
+	switch mode {
+	case "iexact":
+		return strings.EqualFold(name, want)
+	case "partial":
+		return strings.Contains(name, want)
+	case "ipartial":
+		return strings.Contains(strings.ToLower(name), strings.ToLower(want))
+	default: // "exact"
+		return name == want
+	}
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, h := range haystack {
+		if h == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(haystack []Status, needle Status) bool {
+	for _, h := range haystack {
+		if h == needle {
+			return true
+		}
+	}
+	return false
+}
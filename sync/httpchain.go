@@ -0,0 +1,68 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPChain is the default BlockchainClient, talking to the same
+// blockchain HTTP endpoint the rest of the daemon uses for manifest
+// uploads.
+type HTTPChain struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// NewHTTPChain returns an HTTPChain pointed at endpoint with a sane
+// default timeout.
+func NewHTTPChain(endpoint string) *HTTPChain {
+	return &HTTPChain{
+		Endpoint: endpoint,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *HTTPChain) LatestHeight(ctx context.Context) (int, error) {
+	var out struct {
+		Height int `json:"height"`
+	}
+	if err := c.get(ctx, "fula-manifest-height", &out); err != nil {
+		return 0, err
+	}
+	return out.Height, nil
+}
+
+func (c *HTTPChain) ManifestsAt(ctx context.Context, height int) ([]Manifest, error) {
+	var out struct {
+		Manifests []Manifest `json:"manifests"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("fula-manifest-at/%d", height), &out); err != nil {
+		return nil, err
+	}
+	return out.Manifests, nil
+}
+
+func (c *HTTPChain) get(ctx context.Context, action string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint+"/"+action, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("blockchain returned status %d: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,304 @@
+// Package sync reconciles the local IPFS Cluster pinset with the
+// blockchain manifest ledger, so that node restarts, manifests posted by
+// other peers, and cluster pins that failed asynchronously all converge
+// back to the ledger's intent instead of being silently forgotten.
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ipfsClusterApi "github.com/ipfs-cluster/ipfs-cluster/api"
+	"github.com/ipfs/go-cid"
+)
+
+// Manifest is the subset of blockchain manifest state the sync manager
+// needs to reconcile a pin: the CID itself and the pool it belongs to.
+type Manifest struct {
+	Cid    string `json:"cid"`
+	PoolID int    `json:"pool_id"`
+}
+
+// BlockchainClient is the subset of blockchain access the sync manager
+// needs. It is implemented by HTTPChain in httpchain.go; tests can
+// substitute a fake.
+type BlockchainClient interface {
+	// LatestHeight returns the current manifest ledger height.
+	LatestHeight(ctx context.Context) (int, error)
+	// ManifestsAt returns the full desired pinset as of height.
+	ManifestsAt(ctx context.Context, height int) ([]Manifest, error)
+}
+
+// ClusterClient is the subset of the IPFS Cluster REST client the sync
+// manager needs; it is satisfied by ipfsCluster.Client.
+type ClusterClient interface {
+	Allocations(ctx context.Context, filter ipfsClusterApi.PinType, out chan<- ipfsClusterApi.Pin) error
+	Pin(ctx context.Context, ci ipfsClusterApi.Cid, opts ipfsClusterApi.PinOptions) (ipfsClusterApi.Pin, error)
+	Unpin(ctx context.Context, ci ipfsClusterApi.Cid) (ipfsClusterApi.Pin, error)
+}
+
+// pins drains the cluster's full allocation list through the streaming
+// Allocations API into a plain slice, which is far easier for reconcile
+// to diff against the manifest ledger.
+func pins(ctx context.Context, cluster ClusterClient) ([]ipfsClusterApi.Pin, error) {
+	out := make(chan ipfsClusterApi.Pin, 64)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cluster.Allocations(ctx, ipfsClusterApi.AllType, out)
+	}()
+
+	var result []ipfsClusterApi.Pin
+	for p := range out {
+		result = append(result, p)
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Status reports the sync manager's progress, served from GET /sync/status.
+type Status struct {
+	Running      bool      `json:"running"`
+	LastHeight   int       `json:"last_height"`
+	LastSyncedAt time.Time `json:"last_synced_at,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// Manager polls the blockchain manifest ledger and converges the IPFS
+// Cluster pinset onto it. Create one with NewManager and call Start once
+// at daemon startup; Stop releases its goroutine.
+type Manager struct {
+	chain    BlockchainClient
+	cluster  ClusterClient
+	heights  *heightStore
+	interval time.Duration
+
+	// desired caches the manifest set fetched at the last height we saw,
+	// so reconcile can keep re-diffing the cluster pinset against it even
+	// when the ledger height hasn't moved (see reconcile).
+	desired []Manifest
+
+	mu     sync.RWMutex
+	status Status
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager constructs a Manager that persists its progress under
+// storeDir and polls the chain every interval once started.
+func NewManager(chain BlockchainClient, cluster ClusterClient, storeDir string, interval time.Duration) (*Manager, error) {
+	heights, err := newHeightStore(filepath.Join(storeDir, "sync-height.json"))
+	if err != nil {
+		return nil, fmt.Errorf("opening sync height store: %w", err)
+	}
+	return &Manager{
+		chain:    chain,
+		cluster:  cluster,
+		heights:  heights,
+		interval: interval,
+		status:   Status{LastHeight: heights.Get()},
+	}, nil
+}
+
+// Start runs the initial catch-up reconciliation and then launches the
+// steady-state polling loop in the background. It returns once catch-up
+// completes; ctx governs the loop's lifetime until Stop is called.
+func (m *Manager) Start(ctx context.Context) error {
+	if err := m.reconcile(ctx); err != nil {
+		m.recordError(err)
+		return fmt.Errorf("initial catch-up sync failed: %w", err)
+	}
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	m.setRunning(true)
+
+	go m.loop(loopCtx)
+	return nil
+}
+
+// Stop ends the steady-state polling loop and waits for it to exit.
+func (m *Manager) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+	m.setRunning(false)
+}
+
+// Status returns a snapshot of the manager's current progress.
+func (m *Manager) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+func (m *Manager) loop(ctx context.Context) {
+	defer close(m.done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.reconcile(ctx); err != nil {
+				log.Printf("sync: reconciliation failed: %v", err)
+				m.recordError(err)
+			}
+		}
+	}
+}
+
+// reconcile fetches the latest manifest height and, only if it has
+// advanced since the last run (or we have never fetched one), pulls the
+// full desired pinset from the chain. Either way it re-diffs the desired
+// pinset against the cluster's actual pinset and converges with Pin/Unpin
+// calls, so that a cluster pin that failed asynchronously at the current
+// height gets retried on every poll instead of only on ledger advances.
+func (m *Manager) reconcile(ctx context.Context) error {
+	height, err := m.chain.LatestHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching latest manifest height: %w", err)
+	}
+
+	advanced := height != m.heights.Get()
+	if advanced || m.desired == nil {
+		desired, err := m.chain.ManifestsAt(ctx, height)
+		if err != nil {
+			return fmt.Errorf("fetching manifests at height %d: %w", height, err)
+		}
+		m.desired = desired
+	}
+	wanted := make(map[string]struct{}, len(m.desired))
+	for _, manifest := range m.desired {
+		wanted[manifest.Cid] = struct{}{}
+	}
+
+	current, err := pins(ctx, m.cluster)
+	if err != nil {
+		return fmt.Errorf("listing cluster pinset: %w", err)
+	}
+	have := make(map[string]struct{}, len(current))
+	for _, pin := range current {
+		have[pin.Cid.String()] = struct{}{}
+	}
+
+	for cidStr := range wanted {
+		if _, ok := have[cidStr]; ok {
+			continue
+		}
+		c, err := cid.Decode(cidStr)
+		if err != nil {
+			log.Printf("sync: skipping malformed cid %q: %v", cidStr, err)
+			continue
+		}
+		opts := ipfsClusterApi.PinOptions{Mode: ipfsClusterApi.PinModeRecursive}
+		if _, err := m.cluster.Pin(ctx, ipfsClusterApi.NewCid(c), opts); err != nil {
+			log.Printf("sync: failed to pin %s: %v", cidStr, err)
+		}
+	}
+	for cidStr := range have {
+		if _, ok := wanted[cidStr]; ok {
+			continue
+		}
+		c, err := cid.Decode(cidStr)
+		if err != nil {
+			continue
+		}
+		if _, err := m.cluster.Unpin(ctx, ipfsClusterApi.NewCid(c)); err != nil {
+			log.Printf("sync: failed to unpin %s: %v", cidStr, err)
+		}
+	}
+
+	if err := m.heights.Set(height); err != nil {
+		return fmt.Errorf("persisting synced height: %w", err)
+	}
+	m.recordSynced(height)
+	return nil
+}
+
+func (m *Manager) setRunning(running bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.Running = running
+}
+
+func (m *Manager) recordSynced(height int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.LastHeight = height
+	m.status.LastSyncedAt = time.Now()
+	m.status.LastError = ""
+}
+
+func (m *Manager) recordError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.LastError = err.Error()
+}
+
+// heightStore persists the last-synced manifest height in a single small
+// JSON file, so Status reports it across restarts. The first reconcile
+// after a restart still fetches the full desired pinset at the latest
+// height, since Manager.desired starts out empty.
+type heightStore struct {
+	path string
+
+	mu     sync.Mutex
+	height int
+}
+
+func newHeightStore(path string) (*heightStore, error) {
+	s := &heightStore{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var payload struct {
+		Height int `json:"height"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	s.height = payload.Height
+	return s, nil
+}
+
+func (s *heightStore) Get() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.height
+}
+
+func (s *heightStore) Set(height int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(struct {
+		Height int `json:"height"`
+	}{Height: height})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return err
+	}
+	s.height = height
+	return nil
+}